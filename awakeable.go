@@ -0,0 +1,59 @@
+package restate
+
+import "encoding/json"
+
+// Awaitable is a journaled promise that can be waited on with Await. The
+// invocation suspends while the promise is still pending and is resumed
+// once it is completed.
+type Awaitable[T any] interface {
+	Await() (T, error)
+}
+
+type typedAwaitable[T any] struct {
+	raw   Awaitable[[]byte]
+	codec Codec
+}
+
+func (a *typedAwaitable[T]) Await() (T, error) {
+	var zero T
+
+	bytes, err := a.raw.Await()
+	if err != nil {
+		return zero, err
+	}
+
+	var out T
+	if err := a.codec.Unmarshal(bytes, &out); err != nil {
+		return zero, err
+	}
+
+	return out, nil
+}
+
+// Awakeable hands out the ID of a new journaled promise that can be
+// completed by an external system via ResolveAwakeable/RejectAwakeable,
+// together with a typed promise to await its result. It is the standard
+// building block for human-in-the-loop and third-party callback
+// integrations.
+func Awakeable[T any](ctx Context) (id string, promise Awaitable[T]) {
+	id, raw := ctx.Awakeable()
+	return id, &typedAwaitable[T]{raw: raw, codec: jsonCodec{}}
+}
+
+// ResolveAwakeable completes the awakeable identified by id with value,
+// waking up the invocation that is awaiting it.
+func ResolveAwakeable[T any](ctx Context, id string, value T) error {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return ctx.ResolveAwakeable(id, bytes)
+}
+
+// RejectAwakeable fails the awakeable identified by id with reason,
+// causing the invocation awaiting it to receive reason as a terminal
+// error.
+func RejectAwakeable(ctx Context, id string, reason error) error {
+	return ctx.RejectAwakeable(id, reason)
+}
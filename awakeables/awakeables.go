@@ -0,0 +1,60 @@
+// Package awakeables exposes the HTTP endpoint an external system uses to
+// complete an Awakeable handed out via restate.Awakeable, forwarding the
+// completion to the runtime so it can be routed to whichever invocation's
+// journal owns it.
+package awakeables
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/muhamadazmy/restate-sdk-go/internal/state"
+)
+
+// completion is the payload accepted by Handler: either Result or Failure
+// must be set.
+type completion struct {
+	ID      string          `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Failure string          `json:"failure,omitempty"`
+}
+
+// Handler returns an http.Handler that accepts a POSTed
+// {"id": "...", "result": ...} or {"id": "...", "failure": "..."} payload
+// and forwards it to the runtime as a resolve/reject of the corresponding
+// Awakeable. Mount it alongside the invocation handler so external systems
+// have somewhere to POST a completion to.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var body completion
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if body.ID == "" {
+			http.Error(w, "missing id", http.StatusBadRequest)
+			return
+		}
+
+		var err error
+		if body.Failure != "" {
+			err = state.RejectAwakeable(body.ID, errors.New(body.Failure))
+		} else {
+			err = state.ResolveAwakeable(body.ID, body.Result)
+		}
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+}
@@ -0,0 +1,36 @@
+package restate
+
+import "context"
+
+// HealthStatus mirrors the serving status of the standard
+// grpc.health.v1.Health service.
+type HealthStatus int
+
+const (
+	HealthStatusUnknown HealthStatus = iota
+	HealthStatusServing
+	HealthStatusNotServing
+)
+
+func (s HealthStatus) String() string {
+	switch s {
+	case HealthStatusServing:
+		return "SERVING"
+	case HealthStatusNotServing:
+		return "NOT_SERVING"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// HealthProbe reports whether the router it is attached to is ready to
+// serve invocations. A non-nil error is reported as HealthStatusNotServing.
+type HealthProbe func(ctx context.Context) error
+
+// HealthChecker resolves the current HealthStatus of a registered service,
+// by name, so it can be exposed over the standard gRPC health protocol or
+// a plain HTTP health endpoint without the caller needing to know about
+// the Restate invocation protocol.
+type HealthChecker interface {
+	Check(service string) HealthStatus
+}
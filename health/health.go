@@ -0,0 +1,133 @@
+// Package health adapts the health probes attached to restate.Router
+// registrations to the standard grpc.health.v1.Health service, plus a
+// plain JSON /health endpoint, so a deployed Restate service can be probed
+// independently of the invocation protocol (e.g. by a Kubernetes liveness
+// or readiness check).
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	restate "github.com/muhamadazmy/restate-sdk-go"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// pollInterval is how often Watch re-checks a probe for a status change.
+const pollInterval = 5 * time.Second
+
+// Checker implements grpc.health.v1.Health over a set of services
+// registered through NewChecker, each backed by an restate.HealthProbe.
+type Checker struct {
+	healthpb.UnimplementedHealthServer
+
+	services map[string]restate.HealthProbe
+}
+
+// NewChecker builds a Checker from a set of named routers, using the probe
+// each router was given via WithHealthProbe (routers without one are
+// always reported as SERVING).
+func NewChecker(routers map[string]restate.Router) *Checker {
+	c := &Checker{services: make(map[string]restate.HealthProbe, len(routers))}
+	for name, router := range routers {
+		c.services[name] = router.HealthProbe()
+	}
+
+	return c
+}
+
+// Status reports the current restate.HealthStatus of service. It backs the
+// restate.HealthChecker returned by AsHealthChecker and is exposed to
+// callers that want to check a single service without going through it.
+func (c *Checker) Status(service string) restate.HealthStatus {
+	return c.status(context.Background(), service)
+}
+
+// AsHealthChecker adapts Checker to restate.HealthChecker, whose Check
+// method would otherwise collide with the grpc.health.v1.Health/Check
+// method below.
+func (c *Checker) AsHealthChecker() restate.HealthChecker {
+	return healthCheckerAdapter{c}
+}
+
+type healthCheckerAdapter struct {
+	checker *Checker
+}
+
+func (a healthCheckerAdapter) Check(service string) restate.HealthStatus {
+	return a.checker.Status(service)
+}
+
+// Check implements the unary grpc.health.v1.Health/Check method.
+func (c *Checker) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	return &healthpb.HealthCheckResponse{Status: toProto(c.status(ctx, req.Service))}, nil
+}
+
+// Watch implements the streaming grpc.health.v1.Health/Watch method,
+// pushing a new message whenever the probed status changes.
+func (c *Checker) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var last healthpb.HealthCheckResponse_ServingStatus = -1
+	for {
+		status := toProto(c.status(stream.Context(), req.Service))
+		if status != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: status}); err != nil {
+				return err
+			}
+			last = status
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Checker) status(ctx context.Context, service string) restate.HealthStatus {
+	probe, ok := c.services[service]
+	if !ok {
+		return restate.HealthStatusUnknown
+	}
+
+	if probe == nil {
+		return restate.HealthStatusServing
+	}
+
+	if err := probe(ctx); err != nil {
+		return restate.HealthStatusNotServing
+	}
+
+	return restate.HealthStatusServing
+}
+
+func toProto(status restate.HealthStatus) healthpb.HealthCheckResponse_ServingStatus {
+	switch status {
+	case restate.HealthStatusServing:
+		return healthpb.HealthCheckResponse_SERVING
+	case restate.HealthStatusNotServing:
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	default:
+		return healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+	}
+}
+
+// HTTPHandler serves a plain GET /health, returning a JSON object mapping
+// each registered router name to its status, for callers that don't speak
+// gRPC.
+func (c *Checker) HTTPHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := make(map[string]string, len(c.services))
+		for service := range c.services {
+			result[service] = c.status(r.Context(), service).String()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	})
+}
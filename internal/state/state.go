@@ -1,10 +1,17 @@
 package state
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
 	"runtime/debug"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,9 +20,11 @@ import (
 	"github.com/muhamadazmy/restate-sdk-go/generated/proto/dynrpc"
 	"github.com/muhamadazmy/restate-sdk-go/generated/proto/protocol"
 	"github.com/muhamadazmy/restate-sdk-go/internal/wire"
+	"github.com/muhamadazmy/restate-sdk-go/statusx"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -93,6 +102,25 @@ func (c *Context) SideEffect(fn func() ([]byte, error), bo ...backoff.BackOff) (
 	return c.machine.sideEffect(fn, back)
 }
 
+// Log returns a logger scoped to this invocation. Writes made while the
+// journal is being replayed are dropped so side effect callbacks don't
+// duplicate log lines every time they are replayed.
+func (c *Context) Log() *slog.Logger {
+	return c.machine.runLog
+}
+
+func (c *Context) Awakeable() (string, restate.Awaitable[[]byte]) {
+	return c.machine.awakeable()
+}
+
+func (c *Context) ResolveAwakeable(id string, value []byte) error {
+	return c.machine.resolveAwakeable(id, value)
+}
+
+func (c *Context) RejectAwakeable(id string, reason error) error {
+	return c.machine.rejectAwakeable(id, reason)
+}
+
 func newContext(inner context.Context, machine *Machine) *Context {
 
 	// state := make(map[string][]byte)
@@ -122,15 +150,79 @@ type Machine struct {
 	entries    []wire.Message
 	entryIndex int
 
-	log zerolog.Logger
+	// completions holds CompletionMessages received for entries awaiting
+	// external completion (e.g. awakeables), keyed by the entry index
+	// they complete. Unlike entries, these don't occupy a sequential
+	// journal slot of their own.
+	completions map[uint32]*wire.CompletionMessage
+
+	// isReplaying is true while entryIndex is still being satisfied from
+	// previously journaled entries, and false once new entries are being
+	// appended to the log for the first time.
+	isReplaying bool
+
+	log    zerolog.Logger
+	runLog *slog.Logger
+
+	// retryPolicy is the process/router-level RetryPolicy applied to
+	// SideEffect/RunAs calls that don't pass their own RetryPolicy.BackOff()
+	// as their backoff.BackOff.
+	retryPolicy restate.RetryPolicy
+}
+
+// replayAwareHandler drops log records while the owning machine is
+// replaying its journal, so that re-executed side effects don't duplicate
+// log lines that were already emitted on the first pass.
+type replayAwareHandler struct {
+	machine *Machine
+	inner   slog.Handler
+}
+
+func (h *replayAwareHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return !h.machine.isReplaying && h.inner.Enabled(ctx, level)
+}
+
+func (h *replayAwareHandler) Handle(ctx context.Context, record slog.Record) error {
+	if h.machine.isReplaying {
+		return nil
+	}
+	return h.inner.Handle(ctx, record)
+}
+
+func (h *replayAwareHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &replayAwareHandler{machine: h.machine, inner: h.inner.WithAttrs(attrs)}
+}
+
+func (h *replayAwareHandler) WithGroup(name string) slog.Handler {
+	return &replayAwareHandler{machine: h.machine, inner: h.inner.WithGroup(name)}
+}
+
+// MachineOption configures a Machine at construction time.
+type MachineOption func(*Machine)
+
+// WithRetryPolicy sets the process/router-level RetryPolicy applied to
+// SideEffect/RunAs calls made through this Machine that don't specify
+// their own.
+func WithRetryPolicy(policy restate.RetryPolicy) MachineOption {
+	return func(m *Machine) {
+		m.retryPolicy = policy
+	}
 }
 
-func NewMachine(handler restate.Handler, conn io.ReadWriter) *Machine {
-	return &Machine{
-		handler:  handler,
-		protocol: wire.NewProtocol(conn),
-		current:  make(map[string][]byte),
+func NewMachine(handler restate.Handler, conn io.ReadWriter, opts ...MachineOption) *Machine {
+	m := &Machine{
+		handler:     handler,
+		protocol:    wire.NewProtocol(conn),
+		current:     make(map[string][]byte),
+		completions: make(map[uint32]*wire.CompletionMessage),
+		retryPolicy: restate.DefaultRetryPolicy,
+	}
+
+	for _, opt := range opts {
+		opt(m)
 	}
+
+	return m
 }
 
 // Start starts the state machine
@@ -154,6 +246,8 @@ func (m *Machine) Start(inner context.Context, trace string) error {
 	}
 
 	m.log = log.With().Str("id", start.Payload.DebugId).Str("method", trace).Logger()
+	m.runLog = slog.New(&replayAwareHandler{machine: m, inner: slog.NewJSONHandler(os.Stderr, nil)}).
+		With("id", start.Payload.DebugId, "method", trace)
 
 	ctx := newContext(inner, m)
 
@@ -169,13 +263,31 @@ func (m *Machine) output(r *dynrpc.RpcResponse, err error) proto.Message {
 		m.log.Error().Err(err).Msg("failure")
 	}
 
-	if err != nil && restate.IsTerminalError(err) {
+	// if the error carries (or wraps) a *status.Status, e.g. because a
+	// gRPC handler was ported as-is into this Restate handler, it is
+	// terminal on its own: a bare ported gRPC error has no other way of
+	// being marked terminal, and it should not be retried forever.
+	var st *status.Status
+	var isStatus bool
+	if err != nil {
+		st, isStatus = status.FromError(err)
+	}
+
+	if err != nil && (restate.IsTerminalError(err) || isStatus) {
 		// terminal errors.
+		code := restate.ErrorCode(err)
+		message := err.Error()
+
+		if isStatus {
+			code = statusx.FromGRPCCode(st.Code())
+			message = st.Message()
+		}
+
 		return &protocol.OutputStreamEntryMessage{
 			Result: &protocol.OutputStreamEntryMessage_Failure{
 				Failure: &protocol.Failure{
-					Code:    uint32(restate.ErrorCode(err)),
-					Message: err.Error(),
+					Code:    uint32(code),
+					Message: message,
 				},
 			},
 		}
@@ -281,9 +393,25 @@ func (m *Machine) process(ctx *Context, start *wire.StartMessage) error {
 		}
 
 		m.log.Trace().Uint16("type", uint16(msg.Type())).Msg("replay log entry")
+
+		// a CompletionMessage doesn't occupy a sequential journal slot
+		// of its own: it carries the index of the entry it completes
+		// (e.g. an awakeable minted earlier in this same journal), so it
+		// is indexed separately rather than appended to entries.
+		if completion, ok := msg.(*wire.CompletionMessage); ok {
+			m.completions[completion.Payload.GetEntryIndex()] = completion
+			continue
+		}
+
 		m.entries = append(m.entries, msg)
 	}
 
+	// known up front: an invocation with any journaled entries is a
+	// replay from the start, so Log() must suppress writes even before
+	// the handler's first Get/Set/Sleep/SideEffect/Awakeable call walks
+	// entryIndex forward.
+	m.isReplaying = len(m.entries) > 0
+
 	inputMsg := msg.(*wire.PollInputEntry)
 	value := inputMsg.Payload.GetValue()
 	var input dynrpc.RpcRequest
@@ -340,6 +468,7 @@ func replayOrNew[M wire.Message, O any](
 	// if entry exists, we need to replay it
 	// by calling the replay function
 	if ok {
+		m.isReplaying = true
 		if entry.Type() != typ {
 			return output, errEntryMismatch
 		}
@@ -347,5 +476,202 @@ func replayOrNew[M wire.Message, O any](
 	}
 
 	// other wise call the new function
+	m.isReplaying = false
 	return new()
 }
+
+// classifyingBackOff is implemented by a backoff.BackOff that also carries
+// a RetryPolicy's Classifier/MaxElapsedTime (see RetryPolicy.BackOff), so
+// sideEffect can consult it before treating an error as retryable.
+type classifyingBackOff interface {
+	Classify(err error, elapsed time.Duration) error
+}
+
+// sideEffect journals the bytes returned by fn (or replays them), retrying
+// fn per bo until it succeeds or is classified as terminal.
+func (m *Machine) sideEffect(fn func() ([]byte, error), bo backoff.BackOff) ([]byte, error) {
+	return replayOrNew(
+		m,
+		wire.SideEffectEntryMessageType,
+		func(entry *wire.SideEffectEntryMessage) ([]byte, error) {
+			return entry.Payload.GetValue(), nil
+		},
+		func() ([]byte, error) {
+			policy, ok := bo.(classifyingBackOff)
+			if !ok {
+				policy = m.retryPolicy
+				// a call that passed no backoff of its own gets the whole
+				// process/router-level policy, intervals and attempts included.
+				if bo == backoff.BackOff(&restate.DefaultBackoffPolicy) {
+					bo = m.retryPolicy.BackOff()
+				}
+			}
+
+			start := time.Now()
+			var value []byte
+
+			err := backoff.Retry(func() error {
+				v, err := fn()
+				if err == nil {
+					value = v
+					return nil
+				}
+
+				err = policy.Classify(err, time.Since(start))
+				if restate.IsAbortError(err) {
+					// Abort stops retrying but, unlike Terminal, is not
+					// journaled as a Failure: surface the original,
+					// unwrapped error to the caller as-is.
+					return backoff.Permanent(errors.Unwrap(err))
+				}
+				if restate.IsTerminalError(err) {
+					return backoff.Permanent(err)
+				}
+
+				return err
+			}, bo)
+
+			if err != nil {
+				// backoff.Retry already unwraps a *backoff.PermanentError
+				// to the error it carries, so err here is the classified
+				// terminal error, the original unwrapped error for
+				// RetryDecisionAbort, or, once the backoff stops (e.g.
+				// MaxAttempts is reached), the last retryable error.
+				return nil, err
+			}
+
+			if err := m.protocol.Write(&wire.SideEffectEntryMessage{
+				Payload: &protocol.SideEffectEntryMessage{Value: value},
+			}); err != nil {
+				return nil, err
+			}
+
+			return value, nil
+		},
+	)
+}
+
+// awakeablePromise implements restate.Awaitable[[]byte] for an awakeable
+// entry at a fixed journal index.
+type awakeablePromise struct {
+	machine *Machine
+	index   uint32
+}
+
+func (p *awakeablePromise) Await() ([]byte, error) {
+	p.machine.mutex.Lock()
+	defer p.machine.mutex.Unlock()
+
+	// the completion for an awakeable is never stored at p.index in
+	// entries: that slot is (and must stay) the AwakeableEntryMessage
+	// itself, so replaying it keeps matching wire.AwakeableEntryMessageType.
+	// The completion, once delivered, is indexed separately in
+	// m.completions by the index of the entry it completes.
+	completion, ok := p.machine.completions[p.index]
+	if !ok {
+		// not completed yet, suspend until the runtime delivers a
+		// completion for this entry.
+		panic(&suspend{resumeEntry: p.index})
+	}
+
+	if failure := completion.Payload.GetFailure(); failure != nil {
+		return nil, restate.WithErrorCode(
+			restate.TerminalError(errors.New(failure.Message)),
+			restate.Code(failure.Code),
+		)
+	}
+
+	return completion.Payload.GetValue(), nil
+}
+
+type awakeableResult struct {
+	id    string
+	index uint32
+}
+
+// awakeable journals a new awakeable entry (or replays it) and returns its
+// ID together with a promise over its eventual completion.
+func (m *Machine) awakeable() (string, restate.Awaitable[[]byte]) {
+	result, err := replayOrNew(
+		m,
+		wire.AwakeableEntryMessageType,
+		func(entry *wire.AwakeableEntryMessage) (awakeableResult, error) {
+			return awakeableResult{id: entry.Payload.GetId(), index: uint32(m.entryIndex)}, nil
+		},
+		func() (awakeableResult, error) {
+			id := fmt.Sprintf("%s-%d", m.id, m.entryIndex)
+			if err := m.protocol.Write(&wire.AwakeableEntryMessage{
+				Payload: &protocol.AwakeableEntryMessage{Id: id},
+			}); err != nil {
+				return awakeableResult{}, err
+			}
+
+			return awakeableResult{id: id, index: uint32(m.entryIndex)}, nil
+		},
+	)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return result.id, &awakeablePromise{machine: m, index: result.index}
+}
+
+// resolveAwakeable forwards the resolution of id to the runtime. An
+// awakeable is, by design, usually completed by a different invocation (or
+// an external system) than the one that minted it, so this cannot simply
+// write onto this Machine's own connection: it must be routed by the
+// runtime to whichever invocation's journal actually owns that id.
+func (m *Machine) resolveAwakeable(id string, value []byte) error {
+	return ResolveAwakeable(id, value)
+}
+
+// rejectAwakeable forwards the rejection of id to the runtime. See
+// resolveAwakeable.
+func (m *Machine) rejectAwakeable(id string, reason error) error {
+	return RejectAwakeable(id, reason)
+}
+
+// runtimeEndpoint is the base URL of the Restate runtime ingress that owns
+// routing an awakeable completion to the invocation awaiting it, wherever
+// that invocation is running.
+func runtimeEndpoint() string {
+	if v := os.Getenv("RESTATE_RUNTIME_ENDPOINT"); v != "" {
+		return v
+	}
+
+	return "http://localhost:8080"
+}
+
+// ResolveAwakeable forwards the resolution of the awakeable identified by
+// id, with value as its result, to the runtime.
+func ResolveAwakeable(id string, value []byte) error {
+	return completeAwakeable(id, "resolve", bytes.NewReader(value))
+}
+
+// RejectAwakeable forwards the rejection of the awakeable identified by id,
+// with reason as its failure, to the runtime.
+func RejectAwakeable(id string, reason error) error {
+	return completeAwakeable(id, "reject", strings.NewReader(reason.Error()))
+}
+
+func completeAwakeable(id, action string, body io.Reader) error {
+	endpoint := fmt.Sprintf("%s/restate/awakeables/%s/%s", runtimeEndpoint(), url.PathEscape(id), action)
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to %s awakeable %q: %w", action, id, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to %s awakeable %q: unexpected status %s", action, id, resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package restate
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// RetryDecision is returned by a RetryPolicy's Classifier to control how a
+// failed SideEffect/RunAs call is retried.
+type RetryDecision int
+
+const (
+	// RetryDecisionRetry retries the call per the policy's backoff.
+	RetryDecisionRetry RetryDecision = iota
+	// RetryDecisionTerminal turns the error into a terminal error, so it
+	// is journaled as a Failure instead of retried.
+	RetryDecisionTerminal
+	// RetryDecisionAbort stops retrying immediately without journaling a
+	// terminal error, surfacing the error to the caller as-is.
+	RetryDecisionAbort
+)
+
+// RetryPolicy configures how a failed SideEffect/RunAs call is retried. It
+// can be attached process-wide via DefaultRetryPolicy, per-router via
+// UnKeyedRouter.WithRetryPolicy/KeyedRouter.WithRetryPolicy, or per-call by
+// passing RetryPolicy.BackOff() as the call's backoff.BackOff.
+type RetryPolicy struct {
+	InitialInterval     time.Duration
+	MaxInterval         time.Duration
+	Multiplier          float64
+	RandomizationFactor float64
+	// MaxElapsedTime bounds the total time spent retrying. Once exceeded,
+	// the last error is converted into a terminal error rather than
+	// retried forever. Zero means no bound.
+	MaxElapsedTime time.Duration
+	// MaxAttempts bounds the total number of attempts. Zero means no
+	// bound.
+	MaxAttempts int
+	// Classifier decides how a given error should be handled before the
+	// backoff is consulted. A nil Classifier treats every error as
+	// RetryDecisionRetry.
+	Classifier func(error) RetryDecision
+}
+
+// BackOff builds the backoff.BackOff described by the policy, suitable for
+// passing directly to Context.SideEffect or RunAs. The returned value also
+// carries the policy's Classifier and MaxElapsedTime, which
+// Machine.sideEffect consults via the Classify method before treating an
+// error as retryable.
+func (p RetryPolicy) BackOff() backoff.BackOff {
+	eb := backoff.NewExponentialBackOff()
+	if p.InitialInterval > 0 {
+		eb.InitialInterval = p.InitialInterval
+	}
+	if p.MaxInterval > 0 {
+		eb.MaxInterval = p.MaxInterval
+	}
+	if p.Multiplier > 0 {
+		eb.Multiplier = p.Multiplier
+	}
+	if p.RandomizationFactor > 0 {
+		eb.RandomizationFactor = p.RandomizationFactor
+	}
+	// MaxElapsedTime is enforced by Classify, which turns the error into a
+	// terminal one; the inner backoff must not stop on its own first.
+	eb.MaxElapsedTime = 0
+	eb.Reset()
+
+	var bo backoff.BackOff = eb
+	if p.MaxAttempts > 0 {
+		// WithMaxRetries counts retries, i.e. attempts after the first.
+		bo = backoff.WithMaxRetries(bo, uint64(p.MaxAttempts-1))
+	}
+
+	return &retryPolicyBackOff{policy: p, inner: bo}
+}
+
+// retryPolicyBackOff adapts a RetryPolicy to backoff.BackOff while also
+// implementing Classify, so a caller can pass RetryPolicy.BackOff() as a
+// plain backoff.BackOff and still have its Classifier/MaxElapsedTime
+// enforced by the side effect retry loop.
+type retryPolicyBackOff struct {
+	policy RetryPolicy
+	inner  backoff.BackOff
+}
+
+func (b *retryPolicyBackOff) NextBackOff() time.Duration { return b.inner.NextBackOff() }
+
+func (b *retryPolicyBackOff) Reset() { b.inner.Reset() }
+
+func (b *retryPolicyBackOff) Classify(err error, elapsed time.Duration) error {
+	return b.policy.Classify(err, elapsed)
+}
+
+// classify consults the policy's Classifier, defaulting to
+// RetryDecisionRetry when none is set or err is nil.
+func (p RetryPolicy) classify(err error) RetryDecision {
+	if err == nil || p.Classifier == nil {
+		return RetryDecisionRetry
+	}
+
+	return p.Classifier(err)
+}
+
+// abortError wraps an error that the Classifier decided to abort on. It
+// lets Machine.sideEffect tell RetryDecisionAbort apart from the plain
+// "keep retrying" case, while IsAbortError/errors.Unwrap let it recover
+// the original error to surface to the caller as-is, unjournaled.
+type abortError struct {
+	err error
+}
+
+func (e *abortError) Error() string { return e.err.Error() }
+
+func (e *abortError) Unwrap() error { return e.err }
+
+// IsAbortError reports whether err was classified as RetryDecisionAbort by
+// a RetryPolicy's Classifier.
+func IsAbortError(err error) bool {
+	var a *abortError
+	return errors.As(err, &a)
+}
+
+// Classify applies the policy to err, turning it into a terminal error if
+// the Classifier promotes it to RetryDecisionTerminal, or if elapsed has
+// passed MaxElapsedTime (once set) so the invocation doesn't keep retrying
+// forever. A RetryDecisionAbort is wrapped so IsAbortError can recognize
+// it; any other case returns err unchanged so the caller keeps retrying.
+func (p RetryPolicy) Classify(err error, elapsed time.Duration) error {
+	if err == nil {
+		return nil
+	}
+
+	switch p.classify(err) {
+	case RetryDecisionTerminal:
+		return TerminalError(err, ErrorCode(err))
+	case RetryDecisionAbort:
+		return &abortError{err: err}
+	}
+
+	if p.MaxElapsedTime > 0 && elapsed >= p.MaxElapsedTime {
+		return TerminalError(err, ErrorCode(err))
+	}
+
+	return err
+}
+
+// DefaultRetryPolicy is the RetryPolicy applied to SideEffect/RunAs calls
+// that don't specify their own backoff and whose router has no
+// WithRetryPolicy attached.
+var DefaultRetryPolicy = RetryPolicy{}
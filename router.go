@@ -2,8 +2,10 @@ package restate
 
 import (
 	"context"
+	"log/slog"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/muhamadazmy/restate-sdk-go/generated/proto/dynrpc"
 )
 
@@ -35,6 +37,24 @@ type Context interface {
 	Sleep(until time.Time) error
 
 	Service(service string) Service
+
+	// SideEffect journals the bytes returned by fn so that replaying the
+	// invocation returns them again without calling fn a second time.
+	SideEffect(fn func() ([]byte, error), bo ...backoff.BackOff) ([]byte, error)
+
+	// Log returns a logger scoped to this invocation. See RunContext.Log
+	// for replay semantics.
+	Log() *slog.Logger
+
+	// Awakeable journals a new promise and returns its ID together with
+	// the raw (untyped) promise. Use the package-level Awakeable[T]
+	// helper for a typed promise.
+	Awakeable() (id string, promise Awaitable[[]byte])
+	// ResolveAwakeable completes the awakeable identified by id with the
+	// given raw value.
+	ResolveAwakeable(id string, value []byte) error
+	// RejectAwakeable fails the awakeable identified by id with reason.
+	RejectAwakeable(id string, reason error) error
 }
 
 // UnKeyedHandlerFn signature of `un-keyed` handler function
@@ -52,10 +72,18 @@ type Handler interface {
 type Router interface {
 	Keyed() bool
 	Handlers() map[string]Handler
+	// HealthProbe returns the probe attached via WithHealthProbe, or nil
+	// if none was attached.
+	HealthProbe() HealthProbe
+	// RetryPolicy returns the policy attached via WithRetryPolicy, or nil
+	// if none was attached, in which case DefaultRetryPolicy applies.
+	RetryPolicy() *RetryPolicy
 }
 
 type UnKeyedRouter struct {
-	handlers map[string]Handler
+	handlers    map[string]Handler
+	healthProbe HealthProbe
+	retryPolicy *RetryPolicy
 }
 
 func NewUnKeyedRouter() *UnKeyedRouter {
@@ -69,6 +97,13 @@ func (r *UnKeyedRouter) Handler(name string, handler *UnKeyedHandler) *UnKeyedRo
 	return r
 }
 
+// WithHealthProbe attaches a probe that reports whether this router is
+// ready to serve invocations.
+func (r *UnKeyedRouter) WithHealthProbe(probe HealthProbe) *UnKeyedRouter {
+	r.healthProbe = probe
+	return r
+}
+
 func (r *UnKeyedRouter) Keyed() bool {
 	return false
 }
@@ -77,8 +112,25 @@ func (r *UnKeyedRouter) Handlers() map[string]Handler {
 	return r.handlers
 }
 
+func (r *UnKeyedRouter) HealthProbe() HealthProbe {
+	return r.healthProbe
+}
+
+// WithRetryPolicy attaches a RetryPolicy applied to every SideEffect/RunAs
+// call made by this router's handlers, unless overridden per-call.
+func (r *UnKeyedRouter) WithRetryPolicy(policy RetryPolicy) *UnKeyedRouter {
+	r.retryPolicy = &policy
+	return r
+}
+
+func (r *UnKeyedRouter) RetryPolicy() *RetryPolicy {
+	return r.retryPolicy
+}
+
 type KeyedRouter struct {
-	handlers map[string]Handler
+	handlers    map[string]Handler
+	healthProbe HealthProbe
+	retryPolicy *RetryPolicy
 }
 
 func NewKeyedRouter() *KeyedRouter {
@@ -92,6 +144,13 @@ func (r *KeyedRouter) Handler(name string, handler *KeyedHandler) *KeyedRouter {
 	return r
 }
 
+// WithHealthProbe attaches a probe that reports whether this router is
+// ready to serve invocations.
+func (r *KeyedRouter) WithHealthProbe(probe HealthProbe) *KeyedRouter {
+	r.healthProbe = probe
+	return r
+}
+
 func (r *KeyedRouter) Keyed() bool {
 	return true
 }
@@ -99,3 +158,18 @@ func (r *KeyedRouter) Keyed() bool {
 func (r *KeyedRouter) Handlers() map[string]Handler {
 	return r.handlers
 }
+
+func (r *KeyedRouter) HealthProbe() HealthProbe {
+	return r.healthProbe
+}
+
+// WithRetryPolicy attaches a RetryPolicy applied to every SideEffect/RunAs
+// call made by this router's handlers, unless overridden per-call.
+func (r *KeyedRouter) WithRetryPolicy(policy RetryPolicy) *KeyedRouter {
+	r.retryPolicy = &policy
+	return r
+}
+
+func (r *KeyedRouter) RetryPolicy() *RetryPolicy {
+	return r.retryPolicy
+}
@@ -0,0 +1,110 @@
+package restate
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// RunContext is passed to the callback given to RunAs. It gives the
+// callback access to the underlying go context as well as a structured
+// logger that is scoped to the current invocation.
+type RunContext interface {
+	Context() context.Context
+	// Log returns a logger scoped to this invocation. Writes made while
+	// the journal is being replayed are silently dropped so that log
+	// lines are not duplicated every time a side effect is replayed.
+	Log() *slog.Logger
+}
+
+// Codec is used by RunAs to marshal the value returned by fn before it is
+// journaled, and to unmarshal it again on replay. The default is JSON.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) { return json.Marshal(v) }
+
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// RunOption configures a single RunAs call.
+type RunOption func(*runConfig)
+
+type runConfig struct {
+	codec   Codec
+	backoff []backoff.BackOff
+}
+
+// WithCodec overrides the codec RunAs uses to (de)serialize the value
+// returned by fn. Defaults to JSON.
+func WithCodec(codec Codec) RunOption {
+	return func(c *runConfig) {
+		c.codec = codec
+	}
+}
+
+// WithRunBackoff overrides the backoff policy used to retry fn, as per
+// Context.SideEffect.
+func WithRunBackoff(bo backoff.BackOff) RunOption {
+	return func(c *runConfig) {
+		c.backoff = []backoff.BackOff{bo}
+	}
+}
+
+// WithRunRetryPolicy overrides the RetryPolicy used to retry fn. Unlike
+// WithRunBackoff, the policy's Classifier and MaxElapsedTime are enforced
+// by the underlying SideEffect retry loop.
+func WithRunRetryPolicy(policy RetryPolicy) RunOption {
+	return func(c *runConfig) {
+		c.backoff = []backoff.BackOff{policy.BackOff()}
+	}
+}
+
+type runContext struct {
+	ctx context.Context
+	log *slog.Logger
+}
+
+func (r *runContext) Context() context.Context { return r.ctx }
+
+func (r *runContext) Log() *slog.Logger { return r.log }
+
+// RunAs runs fn as a durable side effect, journaling its typed result via
+// Context.SideEffect so that replaying the invocation returns the same
+// value without running fn again. It saves callers from having to
+// serialize/deserialize the result of SideEffect by hand.
+func RunAs[T any](ctx Context, fn func(ctx RunContext) (T, error), opts ...RunOption) (T, error) {
+	var zero T
+
+	cfg := runConfig{codec: jsonCodec{}}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	rc := &runContext{ctx: ctx.Ctx(), log: ctx.Log()}
+
+	bytes, err := ctx.SideEffect(func() ([]byte, error) {
+		value, err := fn(rc)
+		if err != nil {
+			return nil, err
+		}
+
+		return cfg.codec.Marshal(value)
+	}, cfg.backoff...)
+
+	if err != nil {
+		return zero, err
+	}
+
+	var out T
+	if err := cfg.codec.Unmarshal(bytes, &out); err != nil {
+		return zero, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,76 @@
+// Package statusx bridges restate.Code and terminal errors with the
+// standard google.golang.org/grpc/codes and status packages, so existing
+// gRPC handlers and their error plumbing can be ported into Restate
+// services unchanged.
+package statusx
+
+import (
+	"errors"
+
+	restate "github.com/muhamadazmy/restate-sdk-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ToGRPCCode maps a restate.Code onto its equivalent grpc codes.Code. The
+// two enumerations share the same wire values by design.
+func ToGRPCCode(code restate.Code) codes.Code {
+	return codes.Code(code)
+}
+
+// FromGRPCCode maps a grpc codes.Code onto its equivalent restate.Code.
+func FromGRPCCode(code codes.Code) restate.Code {
+	return restate.Code(code)
+}
+
+// Detailer can be implemented by an error to attach structured gRPC status
+// details when it is converted to a status via StatusFromError.
+type Detailer interface {
+	GRPCDetails() []proto.Message
+}
+
+type grpcStatusError interface {
+	GRPCStatus() *status.Status
+}
+
+// TerminalFromStatus builds a terminal error out of a grpc status,
+// preserving its code and message so it can be journaled and later
+// returned to callers unchanged.
+func TerminalFromStatus(st *status.Status) error {
+	if st == nil || st.Code() == codes.OK {
+		return nil
+	}
+
+	return restate.TerminalError(errors.New(st.Message()), FromGRPCCode(st.Code()))
+}
+
+// StatusFromError inspects err for a restate error code (set via
+// restate.WithErrorCode or restate.TerminalError) and produces an
+// equivalent gRPC status, attaching any details the error exposes via
+// Detailer. ok is false if err carries no recognizable status.
+func StatusFromError(err error) (st *status.Status, ok bool) {
+	if err == nil {
+		return nil, false
+	}
+
+	var se grpcStatusError
+	if errors.As(err, &se) {
+		return se.GRPCStatus(), true
+	}
+
+	if !restate.IsTerminalError(err) && restate.ErrorCode(err) == restate.UNKNOWN {
+		return nil, false
+	}
+
+	st = status.New(ToGRPCCode(restate.ErrorCode(err)), err.Error())
+
+	var d Detailer
+	if errors.As(err, &d) {
+		if withDetails, err := st.WithDetails(d.GRPCDetails()...); err == nil {
+			st = withDetails
+		}
+	}
+
+	return st, true
+}